@@ -0,0 +1,153 @@
+package cfg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// bootstrapModule describes one of the built-in modules Bootstrap offers to
+// enable in the generated config
+type bootstrapModule struct {
+	Key       string
+	Label     string
+	RefreshSecs int
+}
+
+// bootstrapModules is the set of modules offered by the first-run wizard.
+// Order here is the order they're presented in, and the order they end up
+// positioned in the generated grid
+var bootstrapModules = []bootstrapModule{
+	{Key: "clocks", Label: "Clocks", RefreshSecs: 15},
+	{Key: "weather", Label: "Weather", RefreshSecs: 3600},
+	{Key: "git", Label: "Git", RefreshSecs: 5},
+	{Key: "security", Label: "Security", RefreshSecs: 3600},
+	{Key: "todo", Label: "Todo", RefreshSecs: 5},
+	{Key: "newrelic", Label: "New Relic", RefreshSecs: 300},
+}
+
+// Bootstrap interactively builds a starter config.yml tailored to the user's
+// answers: grid size and which built-in modules to enable. It writes the
+// result to out as YAML with correct position: blocks and placeholder
+// refresh intervals.
+//
+// When in isn't a terminal (CI, headless startup, piped input with nothing
+// to read), Bootstrap falls back to writing the static defaultConfigFile
+// template so unattended startup is unchanged
+func Bootstrap(out io.Writer, in io.Reader) error {
+	file, isFile := in.(*os.File)
+	if !isFile || !term.IsTerminal(int(file.Fd())) {
+		_, err := io.WriteString(out, defaultConfigFile)
+		return err
+	}
+
+	reader := bufio.NewReader(in)
+
+	fmt.Fprintln(out, "Welcome to wtf! Let's build your dashboard.")
+
+	cols := promptInt(out, reader, "How many columns wide should the grid be?", 3, 1)
+	rows := promptInt(out, reader, "How many rows tall should the grid be?", 2, 1)
+
+	var enabled []bootstrapModule
+	for _, mod := range bootstrapModules {
+		if promptYesNo(out, reader, fmt.Sprintf("Enable the %s module?", mod.Label), true) {
+			enabled = append(enabled, mod)
+		}
+	}
+
+	return writeBootstrapConfig(out, cols, rows, enabled)
+}
+
+/* -------------------- Unexported Functions -------------------- */
+
+// promptInt asks question and reads an integer answer, reprompting on
+// anything below min (e.g. a grid size of 0 or a negative value would panic
+// downstream in writeBootstrapConfig). A read error (EOF on a closed input)
+// falls back to the default rather than looping forever
+func promptInt(out io.Writer, reader *bufio.Reader, question string, fallback int, min int) int {
+	for {
+		fmt.Fprintf(out, "%s [%d]: ", question, fallback)
+
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return fallback
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return fallback
+		}
+
+		value, err := strconv.Atoi(line)
+		if err != nil || value < min {
+			fmt.Fprintf(out, "Please enter a whole number of at least %d.\n", min)
+			continue
+		}
+
+		return value
+	}
+}
+
+func promptYesNo(out io.Writer, reader *bufio.Reader, question string, fallback bool) bool {
+	suffix := "Y/n"
+	if !fallback {
+		suffix = "y/N"
+	}
+
+	fmt.Fprintf(out, "%s [%s]: ", question, suffix)
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return fallback
+	}
+
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "":
+		return fallback
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	default:
+		return fallback
+	}
+}
+
+// writeBootstrapConfig renders the chosen grid size and modules as YAML,
+// laying modules out one per cell, top to bottom, filling each column before
+// moving on to the next
+func writeBootstrapConfig(out io.Writer, cols int, rows int, modules []bootstrapModule) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "wtf:\n")
+	fmt.Fprintf(&b, "  grid:\n")
+	fmt.Fprintf(&b, "    columns: [%s]\n", strings.TrimSuffix(strings.Repeat("40, ", cols), ", "))
+	fmt.Fprintf(&b, "    rows: [%s]\n", strings.TrimSuffix(strings.Repeat("10, ", rows), ", "))
+	fmt.Fprintf(&b, "  mods:\n")
+
+	for i, mod := range modules {
+		fmt.Fprintf(&b, "    %s:\n", mod.Key)
+		fmt.Fprintf(&b, "      enabled: true\n")
+		fmt.Fprintf(&b, "      position:\n")
+		fmt.Fprintf(&b, "        top: %d\n", i%rows)
+		fmt.Fprintf(&b, "        left: %d\n", i/rows)
+		fmt.Fprintf(&b, "        height: 1\n")
+		fmt.Fprintf(&b, "        width: 1\n")
+		fmt.Fprintf(&b, "      refreshInterval: %d\n", mod.RefreshSecs)
+	}
+
+	fmt.Fprintf(&b, "  colors:\n")
+	fmt.Fprintf(&b, "    border:\n")
+	fmt.Fprintf(&b, "      focusable: red\n")
+	fmt.Fprintf(&b, "      focused: orange\n")
+	fmt.Fprintf(&b, "      normal: gray\n")
+	fmt.Fprintf(&b, "  refreshInterval: 1\n")
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
@@ -0,0 +1,112 @@
+package cfg
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestBootstrapNonTTYFallsBackToDefaultConfig(t *testing.T) {
+	var out strings.Builder
+
+	// A strings.Reader is never a *os.File, so Bootstrap takes the non-TTY
+	// path regardless of what's piped into it
+	if err := Bootstrap(&out, strings.NewReader("")); err != nil {
+		t.Fatalf("Bootstrap returned an error: %v", err)
+	}
+
+	if out.String() != defaultConfigFile {
+		t.Fatalf("non-TTY Bootstrap did not fall back to defaultConfigFile:\ngot:  %q\nwant: %q", out.String(), defaultConfigFile)
+	}
+}
+
+func TestPromptIntRepromptsBelowMinimum(t *testing.T) {
+	var out strings.Builder
+	reader := bufio.NewReader(strings.NewReader("-1\n0\n3\n"))
+
+	got := promptInt(&out, reader, "How many columns?", 3, 1)
+
+	if got != 3 {
+		t.Fatalf("promptInt = %d, want 3", got)
+	}
+
+	msg := "Please enter a whole number of at least 1."
+	if count := strings.Count(out.String(), msg); count != 2 {
+		t.Fatalf("expected 2 reprompt messages for the two invalid answers (-1 and 0), got %d:\n%s", count, out.String())
+	}
+}
+
+func TestPromptIntBlankLineUsesFallback(t *testing.T) {
+	var out strings.Builder
+	reader := bufio.NewReader(strings.NewReader("\n"))
+
+	if got := promptInt(&out, reader, "How many rows?", 2, 1); got != 2 {
+		t.Fatalf("promptInt = %d, want fallback 2", got)
+	}
+}
+
+func TestPromptYesNoDefaultsOnBlankLine(t *testing.T) {
+	var out strings.Builder
+	reader := bufio.NewReader(strings.NewReader("\n"))
+
+	if got := promptYesNo(&out, reader, "Enable the clocks module?", true); got != true {
+		t.Fatal("promptYesNo did not fall back to the default (true) on a blank line")
+	}
+}
+
+func TestPromptYesNoParsesNo(t *testing.T) {
+	var out strings.Builder
+	reader := bufio.NewReader(strings.NewReader("n\n"))
+
+	if got := promptYesNo(&out, reader, "Enable the weather module?", true); got != false {
+		t.Fatal("promptYesNo did not parse \"n\" as false")
+	}
+}
+
+func TestWriteBootstrapConfigRendersGridAndModules(t *testing.T) {
+	var out strings.Builder
+
+	modules := []bootstrapModule{
+		{Key: "clocks", Label: "Clocks", RefreshSecs: 15},
+		{Key: "git", Label: "Git", RefreshSecs: 5},
+	}
+
+	if err := writeBootstrapConfig(&out, 2, 1, modules); err != nil {
+		t.Fatalf("writeBootstrapConfig returned an error: %v", err)
+	}
+
+	yaml := out.String()
+
+	wantSubstrings := []string{
+		"columns: [40, 40]",
+		"rows: [10]",
+		"clocks:",
+		"git:",
+		"refreshInterval: 15",
+		"refreshInterval: 5",
+	}
+
+	for _, want := range wantSubstrings {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("generated config missing %q:\n%s", want, yaml)
+		}
+	}
+}
+
+func TestWriteBootstrapConfigNoModulesSelected(t *testing.T) {
+	var out strings.Builder
+
+	if err := writeBootstrapConfig(&out, 3, 2, nil); err != nil {
+		t.Fatalf("writeBootstrapConfig returned an error: %v", err)
+	}
+
+	yaml := out.String()
+
+	if !strings.Contains(yaml, "columns: [40, 40, 40]") {
+		t.Errorf("generated config has wrong column count:\n%s", yaml)
+	}
+
+	if strings.Contains(yaml, "enabled: true") {
+		t.Errorf("generated config should have no modules when none were selected:\n%s", yaml)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
 
 	"github.com/olebedev/config"
 )
@@ -26,8 +27,23 @@ const (
 
 	// WtfSecretsFile defines the file in which to store API Keys and other values you may want to keep out of config.yml
 	WtfSecretsFile = "secrets.yml"
+
+	// EnvConfigDir is the environment variable that, when set, overrides every other
+	// config directory candidate
+	EnvConfigDir = "WTFUTIL_CONFIG_DIR"
+
+	// EnvXdgConfigHome and EnvXdgConfigDirs are the XDG Base Directory environment
+	// variables consulted by ConfigSearchPaths
+	EnvXdgConfigHome = "XDG_CONFIG_HOME"
+	EnvXdgConfigDirs = "XDG_CONFIG_DIRS"
 )
 
+// configPath is the config directory WTF is pinned to, set via SetConfigPath.
+// When empty, WtfConfigDir and ConfigSearchPaths fall back to their normal
+// search behavior. This exists so tests and embedders can point WTF at a
+// temp dir without manipulating environment variables
+var configPath string
+
 /* -------------------- Exported Functions -------------------- */
 
 // CreateFile creates the named file in the config directory, if it does not already exist.
@@ -61,6 +77,16 @@ func CreateFile(fileName string) (string, error) {
 // Initialize takes care of settings up the initial state of WTF configuration
 // It ensures necessary directories and files exist
 func Initialize(hasCustom bool) {
+	// `wtf secrets encrypt|decrypt|rotate` is handled here, before any config
+	// is loaded, so it can run against a config directory that isn't valid yet
+	if len(os.Args) > 1 && os.Args[1] == "secrets" {
+		if err := RunSecretsCommand(os.Args[2:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	if hasCustom == false {
 		migrateOldConfig()
 	}
@@ -77,18 +103,93 @@ func Initialize(hasCustom bool) {
 	}
 }
 
-// WtfConfigDir returns the absolute path to the configuration directory
+// WtfConfigDir returns the absolute path to the primary, writable configuration
+// directory. This is where new config and secrets files are created, and where
+// writes always go, even when an existing config was loaded from a different
+// entry in ConfigSearchPaths.
+//
+// Per the XDG spec, $XDG_CONFIG_DIRS entries are supplemental, typically
+// read-only system search locations (e.g. /etc/xdg) and are never a write
+// target, so they're deliberately excluded here even though ConfigSearchPaths
+// probes them for reads
 func WtfConfigDir() (string, error) {
-	configDir, err := expandHomeDir(WtfConfigDirV2)
-	if err != nil {
-		return "", err
+	if configPath != "" {
+		return configPath, nil
+	}
+
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		return expandHomeDir(dir)
+	}
+
+	if xdgHome := os.Getenv(EnvXdgConfigHome); xdgHome != "" {
+		return expandHomeDir(filepath.Join(xdgHome, "wtf"))
+	}
+
+	return expandHomeDir(WtfConfigDirV2)
+}
+
+// ConfigSearchPaths returns, in priority order, every directory WTF will probe
+// for a config.yml. The first entry is always the primary, writable location
+// returned by WtfConfigDir. When a directory has been pinned via
+// SetConfigPath, it is the only entry; otherwise the order is:
+//
+//  1. $WTFUTIL_CONFIG_DIR, if set
+//  2. $XDG_CONFIG_HOME/wtf
+//  3. ~/.config/wtf, the user's own default
+//  4. each entry of $XDG_CONFIG_DIRS/wtf
+//
+// ~/.config/wtf is placed ahead of $XDG_CONFIG_DIRS because the latter are
+// supplemental, typically read-only system locations (e.g. /etc/xdg); a
+// user's own config should always be found before a system-wide one
+func ConfigSearchPaths() []string {
+	if configPath != "" {
+		return []string{configPath}
+	}
+
+	var paths []string
+
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		paths = append(paths, dir)
+	}
+
+	if xdgHome := os.Getenv(EnvXdgConfigHome); xdgHome != "" {
+		paths = append(paths, filepath.Join(xdgHome, "wtf"))
+	}
+
+	paths = append(paths, WtfConfigDirV2)
+
+	if xdgDirs := os.Getenv(EnvXdgConfigDirs); xdgDirs != "" {
+		for _, dir := range filepath.SplitList(xdgDirs) {
+			if dir != "" {
+				paths = append(paths, filepath.Join(dir, "wtf"))
+			}
+		}
 	}
 
-	return configDir, nil
+	return paths
 }
 
-// LoadWtfConfigFile loads the specified config file
+// SetConfigPath pins WTF to a specific config directory, bypassing the
+// environment-based search in ConfigSearchPaths entirely. Tests and embedders use this to point WTF at a
+// temp dir without manipulating environment variables
+func SetConfigPath(path string) {
+	configPath = path
+}
+
+// GetConfigPath returns the config directory set via SetConfigPath, or the
+// empty string if none has been set
+func GetConfigPath() string {
+	return configPath
+}
+
+// LoadWtfConfigFile loads the config file at filePath. If filePath is empty,
+// each directory in ConfigSearchPaths is probed in order for a config.yml,
+// and the first one that parses is returned
 func LoadWtfConfigFile(filePath string) *config.Config {
+	if filePath == "" {
+		return loadFirstWtfConfigFile()
+	}
+
 	absPath, _ := expandHomeDir(filePath)
 
 	cfg, err := config.ParseYamlFile(absPath)
@@ -100,11 +201,67 @@ func LoadWtfConfigFile(filePath string) *config.Config {
 	return cfg
 }
 
-// LoadWtfSecretsFile loads the specified secrets file
+// loadFirstWtfConfigFile probes ConfigSearchPaths in order and parses the
+// first config.yml it finds
+func loadFirstWtfConfigFile() *config.Config {
+	var lastErr error
+	var lastPath string
+
+	for _, dir := range ConfigSearchPaths() {
+		absDir, err := expandHomeDir(dir)
+		if err != nil {
+			continue
+		}
+
+		candidate := filepath.Join(absDir, WtfConfigFile)
+
+		if _, err := os.Stat(candidate); err != nil {
+			continue
+		}
+
+		cfg, err := config.ParseYamlFile(candidate)
+		if err == nil {
+			return cfg
+		}
+
+		lastErr = err
+		lastPath = candidate
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no %s found on the config search path", WtfConfigFile)
+	}
+
+	displayWtfConfigFileLoadError(lastPath, lastErr)
+	os.Exit(1)
+
+	return nil
+}
+
+// LoadWtfSecretsFile loads the specified secrets file. If the file is in the
+// encrypted-at-rest format (see LoadWtfSecretsFileEncrypted), it's
+// transparently unlocked and decrypted first, so modules reading secrets via
+// the config API continue to work unchanged whether or not encryption is on
 func LoadWtfSecretsFile(filePath string) *config.Config {
 	absPath, _ := expandHomeDir(filePath)
 
-	secrets, err := config.ParseYamlFile(absPath)
+	raw, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		displayWtfConfigFileLoadError(absPath, err)
+		os.Exit(1)
+	}
+
+	if isEncryptedSecretsFile(raw) {
+		secrets, err := LoadWtfSecretsFileEncrypted(absPath, secretsUnlocker())
+		if err != nil {
+			displayWtfConfigFileLoadError(absPath, err)
+			os.Exit(1)
+		}
+
+		return secrets
+	}
+
+	secrets, err := config.ParseYaml(string(raw))
 	if err != nil {
 		displayWtfConfigFileLoadError(absPath, err)
 		os.Exit(1)
@@ -117,10 +274,14 @@ func LoadWtfSecretsFile(filePath string) *config.Config {
 
 // chmodConfigFile sets the mode of the config file to r+w for the owner only
 func chmodConfigFile() {
-	relPath := fmt.Sprintf("%s%s", WtfConfigDirV2, WtfConfigFile)
-	absPath, _ := expandHomeDir(relPath)
+	configDir, err := WtfConfigDir()
+	if err != nil {
+		return
+	}
+
+	absPath := filepath.Join(configDir, WtfConfigFile)
 
-	_, err := os.Stat(absPath)
+	_, err = os.Stat(absPath)
 	if err != nil && os.IsNotExist(err) {
 		return
 	}
@@ -133,10 +294,14 @@ func chmodConfigFile() {
 
 // chmodSecretsFile sets the mode of the Secrets file to r+w for the owner only
 func chmodSecretsFile() {
-	relPath := fmt.Sprintf("%s%s", WtfConfigDirV2, WtfSecretsFile)
-	absPath, _ := expandHomeDir(relPath)
+	configDir, err := WtfConfigDir()
+	if err != nil {
+		return
+	}
+
+	absPath := filepath.Join(configDir, WtfSecretsFile)
 
-	_, err := os.Stat(absPath)
+	_, err = os.Stat(absPath)
 	if err != nil && os.IsNotExist(err) {
 		return
 	}
@@ -175,8 +340,11 @@ func createWtfConfigDir() {
 	}
 }
 
-// createWtfConfigFile creates a simple config file in the config directory if
-// one does not already exist
+// createWtfConfigFile creates a config file in the primary config directory
+// if that directory does not already hold a non-empty one; other directories
+// on the search path are not checked. The content comes from the interactive
+// module wizard in Bootstrap, tailored to the user's answers; Bootstrap itself
+// falls back to the static defaultConfigFile template for non-TTY invocations
 func createWtfConfigFile() {
 	filePath, err := CreateFile(WtfConfigFile)
 	if err != nil {
@@ -188,7 +356,13 @@ func createWtfConfigFile() {
 	file, _ := os.Stat(filePath)
 
 	if file.Size() == 0 {
-		if ioutil.WriteFile(filePath, []byte(defaultConfigFile), 0600) != nil {
+		var generated strings.Builder
+		if err := Bootstrap(&generated, os.Stdin); err != nil {
+			displayDefaultConfigWriteError(err)
+			os.Exit(1)
+		}
+
+		if ioutil.WriteFile(filePath, []byte(generated.String()), 0600) != nil {
 			displayDefaultConfigWriteError(err)
 			os.Exit(1)
 		}
@@ -253,33 +427,17 @@ func home() (string, error) {
 	return currentUser.HomeDir, nil
 }
 
-// migrateOldConfig copies any existing configuration from the old location
-// to the new, XDG-compatible location
+// migrateOldConfig moves any existing configuration from the old location to
+// the new, XDG-compatible location, via Migrate. It's the thin wrapper
+// Initialize calls with the defaults appropriate for an unattended startup
 func migrateOldConfig() {
-	srcDir, _ := expandHomeDir(WtfConfigDirV1)
-	destDir, _ := expandHomeDir(WtfConfigDirV2)
-
-	// If the old config directory doesn't exist, do not move
-	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
-		return
-	}
-
-	// If the new config directory already exists, do not move
-	if _, err := os.Stat(destDir); err == nil {
-		return
-	}
-
-	// Time to move
-	err := Copy(srcDir, destDir)
+	report, err := Migrate(MigrateOptions{Backup: true, Verify: true})
 	if err != nil {
-		panic(err)
+		fmt.Println(err)
+		return
 	}
 
-	// Delete the old directory if the new one exists
-	if _, err := os.Stat(destDir); err == nil {
-		err := os.RemoveAll(srcDir)
-		if err != nil {
-			fmt.Println(err)
-		}
+	if !report.AlreadyDone && len(report.FilesMoved) > 0 {
+		fmt.Printf("Migrated config from %s to %s\n", report.Src, report.Dest)
 	}
 }
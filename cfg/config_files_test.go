@@ -0,0 +1,98 @@
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+
+	for _, key := range []string{EnvConfigDir, EnvXdgConfigHome, EnvXdgConfigDirs} {
+		old, had := os.LookupEnv(key)
+		os.Unsetenv(key)
+
+		t.Cleanup(func() {
+			if had {
+				os.Setenv(key, old)
+			}
+		})
+	}
+
+	SetConfigPath("")
+	t.Cleanup(func() { SetConfigPath("") })
+}
+
+func TestConfigSearchPathsDefault(t *testing.T) {
+	clearConfigEnv(t)
+
+	paths := ConfigSearchPaths()
+	if len(paths) != 1 || paths[0] != WtfConfigDirV2 {
+		t.Fatalf("expected only the default %q, got %v", WtfConfigDirV2, paths)
+	}
+}
+
+func TestConfigSearchPathsPrecedence(t *testing.T) {
+	clearConfigEnv(t)
+
+	os.Setenv(EnvXdgConfigHome, "/home/user/.xdgconfig")
+	os.Setenv(EnvXdgConfigDirs, "/etc/xdg"+string(os.PathListSeparator)+"/opt/xdg")
+	os.Setenv(EnvConfigDir, "/custom/wtf")
+
+	paths := ConfigSearchPaths()
+
+	want := []string{
+		"/custom/wtf",
+		filepath.Join("/home/user/.xdgconfig", "wtf"),
+		WtfConfigDirV2,
+		filepath.Join("/etc/xdg", "wtf"),
+		filepath.Join("/opt/xdg", "wtf"),
+	}
+
+	if len(paths) != len(want) {
+		t.Fatalf("got %v, want %v", paths, want)
+	}
+
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("paths[%d] = %q, want %q (full: %v)", i, paths[i], want[i], paths)
+		}
+	}
+}
+
+func TestWtfConfigDirIgnoresXdgConfigDirs(t *testing.T) {
+	clearConfigEnv(t)
+
+	// XDG_CONFIG_DIRS is supplemental/read-only and must never be chosen as
+	// the writable primary directory, even with XDG_CONFIG_HOME unset
+	os.Setenv(EnvXdgConfigDirs, "/etc/xdg")
+
+	dir, err := WtfConfigDir()
+	if err != nil {
+		t.Fatalf("WtfConfigDir returned an error: %v", err)
+	}
+
+	if dir == filepath.Join("/etc/xdg", "wtf") {
+		t.Fatalf("WtfConfigDir chose a supplemental XDG_CONFIG_DIRS entry as the writable dir: %q", dir)
+	}
+}
+
+func TestWtfConfigDirHonorsSetConfigPath(t *testing.T) {
+	clearConfigEnv(t)
+
+	SetConfigPath("/tmp/wtf-test-config")
+
+	dir, err := WtfConfigDir()
+	if err != nil {
+		t.Fatalf("WtfConfigDir returned an error: %v", err)
+	}
+
+	if dir != "/tmp/wtf-test-config" {
+		t.Fatalf("WtfConfigDir = %q, want %q", dir, "/tmp/wtf-test-config")
+	}
+
+	if got := GetConfigPath(); got != "/tmp/wtf-test-config" {
+		t.Fatalf("GetConfigPath() = %q, want %q", got, "/tmp/wtf-test-config")
+	}
+}
@@ -0,0 +1,263 @@
+package cfg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// migratedMarkerFile is written to the destination directory once a
+// migration completes, so repeated runs are idempotent
+const migratedMarkerFile = ".migrated"
+
+// MigrateOptions controls how Migrate moves an old-layout config tree to the
+// new, XDG-compatible location
+type MigrateOptions struct {
+	// Src and Dest are the source and destination config directories. They
+	// default to WtfConfigDirV1 and WtfConfigDirV2 respectively when empty
+	Src  string
+	Dest string
+
+	// DryRun reports what Migrate would do without mutating anything on disk
+	DryRun bool
+
+	// Backup creates a ~/.wtf.bak-<timestamp>.tar.gz of Src before any mutation
+	Backup bool
+
+	// Verify walks both trees and compares the SHA-256 of each file before
+	// the source is removed
+	Verify bool
+}
+
+// MigrateReport summarizes what Migrate did (or, under DryRun, would do)
+type MigrateReport struct {
+	Src          string
+	Dest         string
+	FilesMoved   []string
+	BackupPath   string
+	AlreadyDone  bool
+	DryRun       bool
+}
+
+// Migrate moves an old-layout (~/.wtf) config tree to the new, XDG-compatible
+// location (~/.config/wtf), replacing the previous migrateOldConfig, which
+// panicked on copy failure and deleted the source with no backup or
+// verification. Migrate returns structured errors instead of panicking, and
+// is idempotent: a destination already carrying a .migrated marker is left
+// alone
+func Migrate(opts MigrateOptions) (MigrateReport, error) {
+	src := opts.Src
+	if src == "" {
+		src, _ = expandHomeDir(WtfConfigDirV1)
+	}
+
+	dest := opts.Dest
+	if dest == "" {
+		dest, _ = expandHomeDir(WtfConfigDirV2)
+	}
+
+	report := MigrateReport{Src: src, Dest: dest, DryRun: opts.DryRun}
+
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return report, nil
+	}
+
+	if _, err := os.Stat(filepath.Join(dest, migratedMarkerFile)); err == nil {
+		report.AlreadyDone = true
+		return report, nil
+	}
+
+	// A destination that exists but has no marker predates Migrate, or is
+	// unrelated user data (e.g. hand-created). Leaving it alone matches the
+	// old migrateOldConfig behavior: skip rather than risk clobbering it
+	if _, err := os.Stat(dest); err == nil {
+		report.AlreadyDone = true
+		return report, nil
+	}
+
+	files, err := listFiles(src)
+	if err != nil {
+		return report, err
+	}
+	report.FilesMoved = files
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	if opts.Backup {
+		backupPath, err := backupDir(src)
+		if err != nil {
+			return report, fmt.Errorf("backup failed, aborting migration: %w", err)
+		}
+		report.BackupPath = backupPath
+	}
+
+	if err := os.Rename(src, dest); err != nil {
+		// Cross-filesystem moves can't use rename; fall back to copy+verify+remove
+		if err := Copy(src, dest); err != nil {
+			return report, fmt.Errorf("copy failed: %w", err)
+		}
+
+		if opts.Verify {
+			if err := verifyTrees(src, dest); err != nil {
+				return report, fmt.Errorf("verification failed, source left in place: %w", err)
+			}
+		}
+
+		if err := os.RemoveAll(src); err != nil {
+			return report, fmt.Errorf("copied to %s but could not remove source %s: %w", dest, src, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dest, migratedMarkerFile), []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0600); err != nil {
+		return report, fmt.Errorf("migration succeeded but could not write %s marker: %w", migratedMarkerFile, err)
+	}
+
+	return report, nil
+}
+
+/* -------------------- Unexported Functions -------------------- */
+
+// listFiles returns every regular file under dir, relative to dir
+func listFiles(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// backupDir tars and gzips dir to ~/.wtf.bak-<timestamp>.tar.gz, returning
+// the backup's path
+func backupDir(dir string) (string, error) {
+	home, err := home()
+	if err != nil {
+		return "", err
+	}
+
+	backupPath := filepath.Join(home, fmt.Sprintf(".wtf.bak-%d.tar.gz", time.Now().Unix()))
+
+	f, err := os.Create(backupPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	defer gz.Close()
+
+	tw := tar.NewWriter(gz)
+	defer tw.Close()
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			if _, err := io.Copy(tw, file); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return backupPath, nil
+}
+
+// verifyTrees walks src and dest and compares the SHA-256 of each regular
+// file, returning an error describing the first mismatch or missing file
+func verifyTrees(src, dest string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		srcSum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+
+		destSum, err := fileSHA256(filepath.Join(dest, rel))
+		if err != nil {
+			return fmt.Errorf("%s missing or unreadable in destination: %w", rel, err)
+		}
+
+		if srcSum != destSum {
+			return fmt.Errorf("%s differs between source and destination", rel)
+		}
+
+		return nil
+	})
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,144 @@
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("could not create dir for %s: %v", path, err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("could not write %s: %v", path, err)
+	}
+}
+
+func TestMigrateDryRunDoesNotMutate(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dest := filepath.Join(root, "dest")
+
+	writeTestFile(t, filepath.Join(src, "config.yml"), "wtf: {}\n")
+
+	report, err := Migrate(MigrateOptions{Src: src, Dest: dest, DryRun: true})
+	if err != nil {
+		t.Fatalf("Migrate returned an error: %v", err)
+	}
+
+	if len(report.FilesMoved) != 1 || report.FilesMoved[0] != "config.yml" {
+		t.Fatalf("expected report to list config.yml, got %v", report.FilesMoved)
+	}
+
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatalf("dry run created the destination directory: %v", err)
+	}
+
+	if _, err := os.Stat(src); err != nil {
+		t.Fatalf("dry run removed the source directory: %v", err)
+	}
+}
+
+func TestMigrateMovesAndMarksDone(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dest := filepath.Join(root, "dest")
+
+	writeTestFile(t, filepath.Join(src, "config.yml"), "wtf: {}\n")
+
+	if _, err := Migrate(MigrateOptions{Src: src, Dest: dest}); err != nil {
+		t.Fatalf("Migrate returned an error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dest, "config.yml")); err != nil {
+		t.Fatalf("config.yml was not moved to dest: %v", err)
+	}
+
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Fatalf("source directory was not removed after migration")
+	}
+
+	if _, err := os.Stat(filepath.Join(dest, migratedMarkerFile)); err != nil {
+		t.Fatalf("%s marker was not written: %v", migratedMarkerFile, err)
+	}
+
+	// Re-running with a fresh (recreated) source must be a no-op: the marker
+	// makes this idempotent
+	writeTestFile(t, filepath.Join(src, "config.yml"), "wtf: {}\n")
+
+	report, err := Migrate(MigrateOptions{Src: src, Dest: dest})
+	if err != nil {
+		t.Fatalf("second Migrate call returned an error: %v", err)
+	}
+
+	if !report.AlreadyDone {
+		t.Fatal("expected AlreadyDone on a destination carrying the marker")
+	}
+}
+
+func TestMigrateSkipsUnmarkedExistingDest(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dest := filepath.Join(root, "dest")
+
+	writeTestFile(t, filepath.Join(src, "config.yml"), "wtf: {}\n")
+	writeTestFile(t, filepath.Join(dest, "config.yml"), "wtf: {unrelated: true}\n")
+
+	report, err := Migrate(MigrateOptions{Src: src, Dest: dest})
+	if err != nil {
+		t.Fatalf("Migrate returned an error for an unmarked existing dest: %v", err)
+	}
+
+	if !report.AlreadyDone {
+		t.Fatal("expected AlreadyDone when dest already exists without a marker")
+	}
+
+	if _, err := os.Stat(src); err != nil {
+		t.Fatalf("source was removed even though migration was skipped: %v", err)
+	}
+}
+
+func TestMigrateNoSourceIsANoOp(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "does-not-exist")
+	dest := filepath.Join(root, "dest")
+
+	report, err := Migrate(MigrateOptions{Src: src, Dest: dest})
+	if err != nil {
+		t.Fatalf("Migrate returned an error when source is missing: %v", err)
+	}
+
+	if report.AlreadyDone {
+		t.Fatal("expected AlreadyDone to be false when there was nothing to migrate")
+	}
+}
+
+func TestVerifyTreesDetectsMismatch(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dest := filepath.Join(root, "dest")
+
+	writeTestFile(t, filepath.Join(src, "secrets.yml"), "github:\n  apiKey: one\n")
+	writeTestFile(t, filepath.Join(dest, "secrets.yml"), "github:\n  apiKey: two\n")
+
+	if err := verifyTrees(src, dest); err == nil {
+		t.Fatal("expected verifyTrees to detect differing file contents")
+	}
+}
+
+func TestVerifyTreesPassesOnIdenticalTrees(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dest := filepath.Join(root, "dest")
+
+	writeTestFile(t, filepath.Join(src, "secrets.yml"), "github:\n  apiKey: one\n")
+	writeTestFile(t, filepath.Join(dest, "secrets.yml"), "github:\n  apiKey: one\n")
+
+	if err := verifyTrees(src, dest); err != nil {
+		t.Fatalf("verifyTrees returned an error for identical trees: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package cfg
+
+import "fmt"
+
+// RunSecretsCommand implements the `wtf secrets encrypt|decrypt|rotate` subcommand.
+// args is the subcommand's argument list with the "secrets" token already stripped off,
+// e.g. []string{"encrypt"}. It operates on the secrets file in the active config directory
+func RunSecretsCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: wtf secrets encrypt|decrypt|rotate")
+	}
+
+	configDir, err := WtfConfigDir()
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("%s/%s", configDir, WtfSecretsFile)
+	unlocker := secretsUnlocker()
+
+	switch args[0] {
+	case "encrypt":
+		return EncryptSecretsFile(path, unlocker)
+	case "decrypt":
+		return DecryptSecretsFile(path, unlocker)
+	case "rotate":
+		return RotateSecretsFile(path, unlocker, PromptUnlocker{Prompt: "Enter new passphrase: "})
+	default:
+		return fmt.Errorf("unknown secrets subcommand %q", args[0])
+	}
+}
+
+// secretsUnlocker picks the first available unlock method: the environment
+// variable, then the OS keyring, falling back to an interactive prompt
+func secretsUnlocker() Unlocker {
+	if _, err := (EnvUnlocker{}).Unlock(); err == nil {
+		return EnvUnlocker{}
+	}
+
+	return KeyringUnlocker{}
+}
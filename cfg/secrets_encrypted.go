@@ -0,0 +1,322 @@
+package cfg
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/olebedev/config"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// KdfScrypt identifies the scrypt key derivation function in an encrypted secrets header
+	KdfScrypt = "scrypt"
+
+	// KdfArgon2id identifies the argon2id key derivation function in an encrypted secrets header
+	KdfArgon2id = "argon2id"
+
+	keyringService = "wtf"
+	keyringUser    = "secrets"
+
+	// EnvSecretsPassphrase is the environment variable consulted by EnvUnlocker
+	EnvSecretsPassphrase = "WTF_SECRETS_PASSPHRASE"
+
+	saltLen  = 16
+	aesKeyLen = 32
+)
+
+/* -------------------- Unlocker -------------------- */
+
+// Unlocker supplies the passphrase used to derive the key that protects an
+// encrypted secrets file. Implementations decide where that passphrase
+// comes from: an interactive prompt, an environment variable, or the OS
+// keyring.
+type Unlocker interface {
+	Unlock() ([]byte, error)
+}
+
+// PromptUnlocker reads a passphrase from the terminal, masking input.
+// It's used the first time a secrets file is unlocked in an interactive session.
+type PromptUnlocker struct {
+	Prompt string
+}
+
+// Unlock reads a passphrase from stdin without echoing it to the terminal
+func (u PromptUnlocker) Unlock() ([]byte, error) {
+	prompt := u.Prompt
+	if prompt == "" {
+		prompt = "Enter passphrase to unlock secrets file: "
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		return passphrase, nil
+	}
+
+	// Not a TTY (e.g. piped input in a test); fall back to a plain read
+	reader := bufio.NewReader(os.Stdin)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+// EnvUnlocker reads the passphrase from the WTF_SECRETS_PASSPHRASE environment variable
+type EnvUnlocker struct{}
+
+// Unlock returns the passphrase stored in the WTF_SECRETS_PASSPHRASE environment variable
+func (u EnvUnlocker) Unlock() ([]byte, error) {
+	passphrase := os.Getenv(EnvSecretsPassphrase)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%s is not set", EnvSecretsPassphrase)
+	}
+
+	return []byte(passphrase), nil
+}
+
+// KeyringUnlocker stores and retrieves the passphrase via the OS keyring
+// (Keychain on macOS, Secret Service on Linux, Credential Manager on Windows)
+type KeyringUnlocker struct{}
+
+// Unlock retrieves the passphrase from the OS keyring, prompting and saving
+// it there on first use
+func (u KeyringUnlocker) Unlock() ([]byte, error) {
+	passphrase, err := keyring.Get(keyringService, keyringUser)
+	if err == nil {
+		return []byte(passphrase), nil
+	}
+
+	entered, err := (PromptUnlocker{}).Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyring.Set(keyringService, keyringUser, string(entered)); err != nil {
+		return nil, fmt.Errorf("could not save passphrase to keyring: %v", err)
+	}
+
+	return entered, nil
+}
+
+/* -------------------- File format -------------------- */
+
+// encryptedSecretsFile is the on-disk representation of an encrypted secrets.yml:
+// a small header describing how the key was derived, plus the AES-GCM
+// ciphertext of the plaintext YAML secrets body
+type encryptedSecretsFile struct {
+	Version    int    `yaml:"version"`
+	KDF        string `yaml:"kdf"`
+	Salt       []byte `yaml:"salt"`
+	Nonce      []byte `yaml:"nonce"`
+	Ciphertext []byte `yaml:"ciphertext"`
+}
+
+// isEncryptedSecretsFile sniffs raw secrets.yml content for the header
+// fields written by encryptSecretsBytes, so LoadWtfSecretsFile can tell an
+// encrypted file from a plaintext one without needing a separate flag
+func isEncryptedSecretsFile(raw []byte) bool {
+	var file encryptedSecretsFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return false
+	}
+
+	return file.KDF != "" && len(file.Ciphertext) > 0
+}
+
+/* -------------------- Exported Functions -------------------- */
+
+// LoadWtfSecretsFileEncrypted loads and decrypts the specified secrets file,
+// unlocking it with the given Unlocker. Once decrypted, the result is parsed
+// exactly like a plaintext secrets.yml, so modules reading secrets via the
+// config API continue to work unchanged
+func LoadWtfSecretsFileEncrypted(path string, unlocker Unlocker) (*config.Config, error) {
+	absPath, _ := expandHomeDir(path)
+
+	raw, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decryptSecretsBytes(raw, unlocker)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.ParseYaml(string(plaintext))
+}
+
+// EncryptSecretsFile converts a plaintext secrets.yml at path into the
+// encrypted format in place, preserving the file's 0600 permissions
+func EncryptSecretsFile(path string, unlocker Unlocker) error {
+	absPath, _ := expandHomeDir(path)
+
+	plaintext, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return err
+	}
+
+	if isEncryptedSecretsFile(plaintext) {
+		return fmt.Errorf("%s is already encrypted", absPath)
+	}
+
+	passphrase, err := unlocker.Unlock()
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encryptSecretsBytes(plaintext, passphrase)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(absPath, encrypted, 0600)
+}
+
+// DecryptSecretsFile converts an encrypted secrets file at path back to
+// plaintext YAML in place, preserving the file's 0600 permissions
+func DecryptSecretsFile(path string, unlocker Unlocker) error {
+	absPath, _ := expandHomeDir(path)
+
+	raw, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := decryptSecretsBytes(raw, unlocker)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(absPath, plaintext, 0600)
+}
+
+// RotateSecretsFile decrypts a secrets file with oldUnlocker and re-encrypts
+// it with the passphrase newUnlocker supplies, so the passphrase can be
+// changed without ever writing the plaintext to disk. A passphrase stored in
+// the OS keyring is not updated
+func RotateSecretsFile(path string, oldUnlocker, newUnlocker Unlocker) error {
+	absPath, _ := expandHomeDir(path)
+
+	raw, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := decryptSecretsBytes(raw, oldUnlocker)
+	if err != nil {
+		return err
+	}
+
+	newPassphrase, err := newUnlocker.Unlock()
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := encryptSecretsBytes(plaintext, newPassphrase)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(absPath, encrypted, 0600)
+}
+
+/* -------------------- Unexported Functions -------------------- */
+
+func encryptSecretsBytes(plaintext []byte, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(KdfArgon2id, passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	file := encryptedSecretsFile{
+		Version:    1,
+		KDF:        KdfArgon2id,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}
+
+	return yaml.Marshal(file)
+}
+
+func decryptSecretsBytes(raw []byte, unlocker Unlocker) ([]byte, error) {
+	var file encryptedSecretsFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("malformed encrypted secrets file: %v", err)
+	}
+
+	passphrase, err := unlocker.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(file.KDF, passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt secrets file, wrong passphrase?: %v", err)
+	}
+
+	return plaintext, nil
+}
+
+func deriveKey(kdf string, passphrase []byte, salt []byte) ([]byte, error) {
+	switch kdf {
+	case KdfArgon2id:
+		return argon2.IDKey(passphrase, salt, 1, 64*1024, 4, aesKeyLen), nil
+	case KdfScrypt:
+		return scrypt.Key(passphrase, salt, 1<<15, 8, 1, aesKeyLen)
+	default:
+		return nil, fmt.Errorf("unsupported key derivation function %q", kdf)
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
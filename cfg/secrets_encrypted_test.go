@@ -0,0 +1,89 @@
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDecryptSecretsBytesRoundTrip(t *testing.T) {
+	plaintext := []byte("github:\n  apiKey: abc123\n")
+
+	encrypted, err := encryptSecretsBytes(plaintext, []byte("correct horse battery staple"))
+	if err != nil {
+		t.Fatalf("encryptSecretsBytes returned an error: %v", err)
+	}
+
+	if !isEncryptedSecretsFile(encrypted) {
+		t.Fatal("isEncryptedSecretsFile did not recognize freshly encrypted bytes")
+	}
+
+	decrypted, err := decryptSecretsBytes(encrypted, staticUnlocker("correct horse battery staple"))
+	if err != nil {
+		t.Fatalf("decryptSecretsBytes returned an error: %v", err)
+	}
+
+	if string(decrypted) != string(plaintext) {
+		t.Fatalf("round trip mismatch: got %q, want %q", decrypted, plaintext)
+	}
+}
+
+func TestDecryptSecretsBytesWrongPassphrase(t *testing.T) {
+	plaintext := []byte("github:\n  apiKey: abc123\n")
+
+	encrypted, err := encryptSecretsBytes(plaintext, []byte("correct horse battery staple"))
+	if err != nil {
+		t.Fatalf("encryptSecretsBytes returned an error: %v", err)
+	}
+
+	if _, err := decryptSecretsBytes(encrypted, staticUnlocker("wrong passphrase")); err == nil {
+		t.Fatal("expected an error when decrypting with the wrong passphrase, got nil")
+	}
+}
+
+func TestIsEncryptedSecretsFilePlaintext(t *testing.T) {
+	plaintext := []byte("github:\n  apiKey: abc123\n")
+
+	if isEncryptedSecretsFile(plaintext) {
+		t.Fatal("plaintext secrets.yml was misidentified as encrypted")
+	}
+}
+
+func TestEncryptSecretsFileRejectsAlreadyEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yml")
+
+	if err := os.WriteFile(path, []byte("github:\n  apiKey: abc123\n"), 0600); err != nil {
+		t.Fatalf("could not write test secrets file: %v", err)
+	}
+
+	unlocker := staticUnlocker("correct horse battery staple")
+
+	if err := EncryptSecretsFile(path, unlocker); err != nil {
+		t.Fatalf("first EncryptSecretsFile call returned an error: %v", err)
+	}
+
+	encryptedOnce, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read encrypted secrets file: %v", err)
+	}
+
+	if err := EncryptSecretsFile(path, unlocker); err == nil {
+		t.Fatal("expected EncryptSecretsFile to refuse to re-encrypt an already-encrypted file")
+	}
+
+	encryptedAfterSecondCall, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read secrets file after second EncryptSecretsFile call: %v", err)
+	}
+
+	if string(encryptedOnce) != string(encryptedAfterSecondCall) {
+		t.Fatal("EncryptSecretsFile overwrote an already-encrypted file instead of refusing, losing the original secrets")
+	}
+}
+
+// staticUnlocker is an Unlocker for tests that always returns a fixed passphrase
+type staticUnlocker string
+
+func (u staticUnlocker) Unlock() ([]byte, error) {
+	return []byte(u), nil
+}
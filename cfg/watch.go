@@ -0,0 +1,173 @@
+package cfg
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/olebedev/config"
+)
+
+// ModuleChangeKind describes how a module's config sub-tree changed between
+// two successive loads
+type ModuleChangeKind int
+
+const (
+	// ModuleAdded means the module key is new in the updated config
+	ModuleAdded ModuleChangeKind = iota
+
+	// ModuleRemoved means the module key was present in the old config but is gone
+	ModuleRemoved
+
+	// ModuleModified means the module key exists in both, but its sub-tree hash changed
+	ModuleModified
+)
+
+// ModuleChange describes a single module whose config sub-tree differs
+// between two config.Config values, as returned by Diff
+type ModuleChange struct {
+	Path string
+	Kind ModuleChangeKind
+}
+
+// Watch watches path (a config.yml or secrets.yml) for writes and emits a
+// freshly parsed *config.Config on the returned channel each time it
+// changes. Callers typically pair this with Diff to restart only the
+// widgets whose module sub-tree actually changed, rather than the whole TUI.
+//
+// Both returned channels, and the underlying watcher, are closed when ctx
+// is cancelled
+func Watch(ctx context.Context, path string) (<-chan *config.Config, <-chan error, error) {
+	absPath, err := expandHomeDir(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		watcher.Close()
+		return nil, nil, err
+	}
+
+	configs := make(chan *config.Config)
+	errs := make(chan error)
+
+	go func() {
+		defer watcher.Close()
+		defer close(configs)
+		defer close(errs)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) != filepath.Clean(absPath) {
+					continue
+				}
+
+				if !event.Op.Has(fsnotify.Write) && !event.Op.Has(fsnotify.Create) {
+					continue
+				}
+
+				cfg, err := config.ParseYamlFile(absPath)
+				if err != nil {
+					select {
+					case errs <- err:
+					case <-ctx.Done():
+						return
+					}
+					continue
+				}
+
+				select {
+				case configs <- cfg:
+				case <-ctx.Done():
+					return
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return configs, errs, nil
+}
+
+// Diff compares the module trees of old and new, matching modules by their
+// config key, and returns every module that was added, removed, or whose
+// sub-tree hash changed
+func Diff(old, new *config.Config) []ModuleChange {
+	oldMods := moduleMap(old)
+	newMods := moduleMap(new)
+
+	var changes []ModuleChange
+
+	for key, oldHash := range oldMods {
+		newHash, present := newMods[key]
+		if !present {
+			changes = append(changes, ModuleChange{Path: key, Kind: ModuleRemoved})
+			continue
+		}
+
+		if oldHash != newHash {
+			changes = append(changes, ModuleChange{Path: key, Kind: ModuleModified})
+		}
+	}
+
+	for key := range newMods {
+		if _, present := oldMods[key]; !present {
+			changes = append(changes, ModuleChange{Path: key, Kind: ModuleAdded})
+		}
+	}
+
+	return changes
+}
+
+// moduleMap builds a map of module key (the <name> in "wtf.mods.<name>") to a hash of its
+// sub-tree, so Diff can cheaply tell which modules changed
+func moduleMap(cfg *config.Config) map[string]string {
+	mods := map[string]string{}
+
+	if cfg == nil {
+		return mods
+	}
+
+	modsMap, err := cfg.Map("wtf.mods")
+	if err != nil {
+		return mods
+	}
+
+	for key, subTree := range modsMap {
+		mods[key] = hashModule(subTree)
+	}
+
+	return mods
+}
+
+// hashModule returns a stable hash of a module's sub-tree, good enough to
+// detect "did anything in this module's config change"
+func hashModule(subTree interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", subTree)))
+	return fmt.Sprintf("%x", sum)
+}
@@ -0,0 +1,174 @@
+package cfg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/olebedev/config"
+)
+
+func TestWatchEmitsParsedConfigOnWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+
+	if err := os.WriteFile(path, []byte("wtf:\n  mods:\n    clocks:\n      enabled: true\n"), 0600); err != nil {
+		t.Fatalf("could not write initial config: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	configs, errs, err := Watch(ctx, path)
+	if err != nil {
+		t.Fatalf("Watch returned an error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("wtf:\n  mods:\n    clocks:\n      enabled: true\n    git:\n      enabled: true\n"), 0600); err != nil {
+		t.Fatalf("could not rewrite config: %v", err)
+	}
+
+	select {
+	case cfg, ok := <-configs:
+		if !ok {
+			t.Fatal("configs channel closed before emitting the updated config")
+		}
+
+		mods, err := cfg.Map("wtf.mods")
+		if err != nil {
+			t.Fatalf("parsed config has no wtf.mods: %v", err)
+		}
+
+		if _, present := mods["git"]; !present {
+			t.Fatalf("parsed config missing the newly added git module: %v", mods)
+		}
+
+	case err := <-errs:
+		t.Fatalf("Watch reported an error instead of a config: %v", err)
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Watch to notice the file write")
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-configs:
+		if ok {
+			t.Fatal("configs channel should be closed after ctx is cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the configs channel to close after cancellation")
+	}
+
+	select {
+	case _, ok := <-errs:
+		if ok {
+			t.Fatal("errs channel should be closed after ctx is cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the errs channel to close after cancellation")
+	}
+}
+
+func mustParseYaml(t *testing.T, yaml string) *config.Config {
+	t.Helper()
+
+	cfg, err := config.ParseYaml(yaml)
+	if err != nil {
+		t.Fatalf("could not parse test YAML: %v", err)
+	}
+
+	return cfg
+}
+
+func TestDiffDetectsAddedRemovedModified(t *testing.T) {
+	old := mustParseYaml(t, `
+wtf:
+  mods:
+    clocks:
+      enabled: true
+      refreshInterval: 15
+    git:
+      enabled: true
+      refreshInterval: 5
+`)
+
+	new := mustParseYaml(t, `
+wtf:
+  mods:
+    clocks:
+      enabled: true
+      refreshInterval: 30
+    weather:
+      enabled: true
+      refreshInterval: 3600
+`)
+
+	changes := Diff(old, new)
+
+	got := map[string]ModuleChangeKind{}
+	for _, c := range changes {
+		got[c.Path] = c.Kind
+	}
+
+	want := map[string]ModuleChangeKind{
+		"clocks":  ModuleModified,
+		"git":     ModuleRemoved,
+		"weather": ModuleAdded,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Diff returned %d changes, want %d (%v)", len(got), len(want), changes)
+	}
+
+	for path, wantKind := range want {
+		gotKind, ok := got[path]
+		if !ok {
+			t.Fatalf("Diff did not report a change for %q", path)
+		}
+		if gotKind != wantKind {
+			t.Fatalf("Diff reported %q as kind %v, want %v", path, gotKind, wantKind)
+		}
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	yaml := `
+wtf:
+  mods:
+    clocks:
+      enabled: true
+      refreshInterval: 15
+`
+	old := mustParseYaml(t, yaml)
+	new := mustParseYaml(t, yaml)
+
+	if changes := Diff(old, new); len(changes) != 0 {
+		t.Fatalf("expected no changes for identical config, got %v", changes)
+	}
+}
+
+func TestDiffHandlesNilConfigs(t *testing.T) {
+	new := mustParseYaml(t, `
+wtf:
+  mods:
+    clocks:
+      enabled: true
+`)
+
+	changes := Diff(nil, new)
+
+	var paths []string
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	sort.Strings(paths)
+
+	if len(paths) != 1 || paths[0] != "clocks" {
+		t.Fatalf("Diff(nil, new) = %v, want a single ModuleAdded change for clocks", changes)
+	}
+}
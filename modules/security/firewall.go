@@ -1,9 +1,7 @@
 package security
 
 import (
-	"bytes"
 	"os/exec"
-	"runtime"
 	"strings"
 
 	"github.com/wtfutil/wtf/utils"
@@ -11,108 +9,174 @@ import (
 
 const osxFirewallCmd = "/usr/libexec/ApplicationFirewall/socketfilterfw"
 
+// State is the on/off/unknown state reported by a FirewallBackend
+type State int
+
+const (
+	// StateUnknown means the backend could not determine a state
+	StateUnknown State = iota
+
+	// StateDisabled means the firewall is present but turned off
+	StateDisabled
+
+	// StateEnabled means the firewall is turned on
+	StateEnabled
+
+	// StateNeedsConfig means the backend's tooling is present but not usable
+	// as configured (e.g. UFW requires passwordless sudo). The Windows backend
+	// also reports it when only some of its profiles are enabled
+	StateNeedsConfig
+)
+
+// forcedBackend is the backend name set via the security.firewallBackend
+// config key, if any. An empty string means auto-select
+var forcedBackend string
+
+// SetForcedFirewallBackend forces FirewallState and FirewallStealthState to
+// use the named backend instead of auto-selecting one. This is wired up from
+// the security.firewallBackend config key for users with unusual setups
+func SetForcedFirewallBackend(name string) {
+	forcedBackend = name
+}
+
+// FirewallBackend is a single firewall implementation (UFW, firewalld,
+// nftables, pfctl, the Windows profile API, ...). FirewallState and
+// FirewallStealthState auto-select the first backend whose binary exists
+type FirewallBackend interface {
+	// Name identifies the backend, and is what the security.firewallBackend
+	// config key matches against
+	Name() string
+
+	// Available reports whether this backend's tooling is present on the
+	// current system
+	Available() bool
+
+	// Enabled reports whether the firewall is turned on
+	Enabled() (State, error)
+
+	// Stealth reports whether stealth mode (not responding to pings from
+	// unauthorized devices) is turned on
+	Stealth() (State, error)
+}
+
+// firewallBackends is the registry of backends probed by selectFirewallBackend,
+// in priority order. applicationFirewallBackend is listed ahead of
+// pfctlBackend: /sbin/pfctl is present on essentially every stock macOS
+// install whether or not the user has ever touched pf, so if it came first
+// it would silently shadow the Application Firewall probe that System
+// Settings > Firewall actually controls
+var firewallBackends = []FirewallBackend{
+	ufwBackend{},
+	firewalldBackend{},
+	nftablesBackend{},
+	iptablesBackend{},
+	applicationFirewallBackend{},
+	pfctlBackend{},
+	windowsProfileBackend{},
+}
+
 /* -------------------- Exported Functions -------------------- */
 
+// FirewallState returns the enabled/disabled state of the firewall, as
+// reported by the first available backend for the current platform, or the
+// one forced via SetForcedFirewallBackend
 func FirewallState() string {
-	switch runtime.GOOS {
-	case "linux":
-		return firewallStateLinux()
-	case "darwin":
-		return firewallStateMacOS()
-	case "windows":
-		return firewallStateWindows()
-	default:
+	backend := selectFirewallBackend()
+	if backend == nil {
 		return ""
 	}
-}
 
-func FirewallStealthState() string {
-	switch runtime.GOOS {
-	case "linux":
-		return firewallStealthStateLinux()
-	case "darwin":
-		return firewallStealthStateMacOS()
-	case "windows":
-		return firewallStealthStateWindows()
-	default:
-		return ""
+	state, err := backend.Enabled()
+	if err != nil {
+		return "[red]Config Needed[white]"
 	}
+
+	return stateLabel(state)
 }
 
-/* -------------------- Unexported Functions -------------------- */
+// FirewallProfileDetail returns a per-profile breakdown of the firewall
+// state when the selected backend can report one (currently only the
+// Windows backend's Domain/Private/Public profiles). It returns the empty
+// string for backends that only report a single aggregate state
+func FirewallProfileDetail() string {
+	backend := selectFirewallBackend()
 
-func firewallStateLinux() string {
-/* This function requires UFW, and configuration to allow "ufw status"
-   without a sudo password (unless running as root). See
-   https://wtfutil.com/modules/security/#for-linux-firewall-users for
-   more details.
-*/
+	if _, ok := backend.(windowsProfileBackend); !ok {
+		return ""
+	}
 
-	cmd := exec.Command("sudo", "ufw", "status")
+	profiles, err := getWindowsFirewallProfiles()
+	if err != nil {
+		return ""
+	}
 
-	var o bytes.Buffer
-	cmd.Stdout = &o
-	if err := cmd.Run(); err != nil {
-		return "[red]Config Needed[white]"
+	return windowsProfileLabel(profiles)
+}
+
+// FirewallStealthState returns the stealth-mode state of the firewall, as
+// reported by the first available backend for the current platform, or the
+// one forced via SetForcedFirewallBackend
+func FirewallStealthState() string {
+	backend := selectFirewallBackend()
+	if backend == nil {
+		return ""
 	}
 
-	if strings.Contains(o.String(), "inactive") {
-		return "[red]Disabled[white]"
-	} else {
-		return "[green]Enabled[white]"
+	state, err := backend.Stealth()
+	if err != nil {
+		return "[white]N/A[white]"
 	}
+
+	return stateLabel(state)
 }
 
-func firewallStateMacOS() string {
-	cmd := exec.Command(osxFirewallCmd, "--getglobalstate")
-	str := utils.ExecuteCommand(cmd)
+/* -------------------- Unexported Functions -------------------- */
+
+// selectFirewallBackend returns the forced backend if one is set and
+// available, otherwise the first available backend appropriate to the
+// current platform
+func selectFirewallBackend() FirewallBackend {
+	if forcedBackend != "" {
+		for _, backend := range firewallBackends {
+			if backend.Name() == forcedBackend && backend.Available() {
+				return backend
+			}
+		}
+	}
+
+	for _, backend := range firewallBackends {
+		if backend.Available() {
+			return backend
+		}
+	}
 
-	return statusLabel(str)
+	return nil
 }
 
-func firewallStateWindows() string {
-	// The raw way to do this in PS, not using netsh, nor registry, is the following:
-	//   if (((Get-NetFirewallProfile | select name,enabled)
-	//                                | where { $_.Enabled -eq $True } | measure ).Count -eq 3)
-	//   { Write-Host "OK" -ForegroundColor Green} else { Write-Host "OFF" -ForegroundColor Red }
-
-	cmd := exec.Command("powershell.exe", "-NoProfile",
-		"-Command", "& { ((Get-NetFirewallProfile | select name,enabled) | where { $_.Enabled -eq $True } | measure ).Count }")
-
-	fwStat := utils.ExecuteCommand(cmd)
-	fwStat = strings.TrimSpace(fwStat) // Always sanitize PowerShell output:  "3\r\n"
-	//fmt.Printf("%d %q\n", len(fwStat), fwStat)
-
-	switch fwStat {
-	case "3":
-		return "[green]Good[white] (3/3)"
-	case "2":
-		return "[orange]Poor[white] (2/3)"
-	case "1":
-		return "[yellow]Bad[white] (1/3)"
-	case "0":
+func stateLabel(state State) string {
+	switch state {
+	case StateEnabled:
+		return "[green]Enabled[white]"
+	case StateDisabled:
 		return "[red]Disabled[white]"
+	case StateNeedsConfig:
+		return "[red]Config Needed[white]"
 	default:
 		return "[white]N/A[white]"
 	}
 }
 
-/* -------------------- Getting Stealth State ------------------- */
-// "Stealth": Not responding to pings from unauthorized devices
-
-func firewallStealthStateLinux() string {
-	return "[white]N/A[white]"
-}
-
-func firewallStealthStateMacOS() string {
-	cmd := exec.Command(osxFirewallCmd, "--getstealthmode")
-	str := utils.ExecuteCommand(cmd)
-
-	return statusLabel(str)
+// runCommand runs name with args and returns the output utils.ExecuteCommand
+// captures for it, untrimmed; callers trim or search it themselves.
+// A non-zero exit is not treated as an error here; callers inspect output
+func runCommand(name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	return utils.ExecuteCommand(cmd), nil
 }
 
-func firewallStealthStateWindows() string {
-	return "[white]N/A[white]"
+func binaryExists(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
 }
 
 func statusLabel(str string) string {
@@ -0,0 +1,273 @@
+package security
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/wtfutil/wtf/utils"
+)
+
+/* -------------------- UFW (Linux) -------------------- */
+
+type ufwBackend struct{}
+
+func (b ufwBackend) Name() string { return "ufw" }
+
+func (b ufwBackend) Available() bool {
+	return runtime.GOOS == "linux" && binaryExists("ufw")
+}
+
+/* This backend requires UFW, and configuration to allow "ufw status"
+   without a sudo password (unless running as root). See
+   https://wtfutil.com/modules/security/#for-linux-firewall-users for
+   more details.
+*/
+func (b ufwBackend) Enabled() (State, error) {
+	cmd := exec.Command("sudo", "ufw", "status")
+
+	var o strings.Builder
+	cmd.Stdout = &o
+	if err := cmd.Run(); err != nil {
+		return StateNeedsConfig, nil
+	}
+
+	if strings.Contains(o.String(), "inactive") {
+		return StateDisabled, nil
+	}
+
+	return StateEnabled, nil
+}
+
+func (b ufwBackend) Stealth() (State, error) {
+	return StateUnknown, nil
+}
+
+/* -------------------- firewalld (Linux) -------------------- */
+
+type firewalldBackend struct{}
+
+func (b firewalldBackend) Name() string { return "firewalld" }
+
+func (b firewalldBackend) Available() bool {
+	return runtime.GOOS == "linux" && binaryExists("firewall-cmd")
+}
+
+func (b firewalldBackend) Enabled() (State, error) {
+	out, _ := runCommand("firewall-cmd", "--state")
+	return parseFirewalldState(out), nil
+}
+
+// parseFirewalldState interprets the output of `firewall-cmd --state`, which
+// is exactly "running" when the daemon is active and "not running" (among
+// other variants) otherwise - a plain strings.Contains(out, "running") would
+// match both and report enabled even when firewalld is off
+func parseFirewalldState(out string) State {
+	if strings.TrimSpace(out) == "running" {
+		return StateEnabled
+	}
+
+	return StateDisabled
+}
+
+func (b firewalldBackend) Stealth() (State, error) {
+	return StateUnknown, nil
+}
+
+/* -------------------- nftables (Linux) -------------------- */
+
+type nftablesBackend struct{}
+
+func (b nftablesBackend) Name() string { return "nftables" }
+
+func (b nftablesBackend) Available() bool {
+	return runtime.GOOS == "linux" && binaryExists("nft")
+}
+
+func (b nftablesBackend) Enabled() (State, error) {
+	out, _ := runCommand("nft", "list", "ruleset")
+
+	if strings.TrimSpace(out) == "" {
+		return StateDisabled, nil
+	}
+
+	return StateEnabled, nil
+}
+
+func (b nftablesBackend) Stealth() (State, error) {
+	return StateUnknown, nil
+}
+
+/* -------------------- iptables fallback (Linux) -------------------- */
+
+type iptablesBackend struct{}
+
+func (b iptablesBackend) Name() string { return "iptables" }
+
+func (b iptablesBackend) Available() bool {
+	return runtime.GOOS == "linux" && binaryExists("iptables")
+}
+
+func (b iptablesBackend) Enabled() (State, error) {
+	out, _ := runCommand("iptables", "-L")
+
+	if strings.TrimSpace(out) == "" {
+		return StateNeedsConfig, nil
+	}
+
+	if strings.Contains(out, "DROP") || strings.Contains(out, "REJECT") {
+		return StateEnabled, nil
+	}
+
+	return StateDisabled, nil
+}
+
+func (b iptablesBackend) Stealth() (State, error) {
+	return StateUnknown, nil
+}
+
+/* -------------------- pfctl (BSD/macOS) -------------------- */
+
+type pfctlBackend struct{}
+
+func (b pfctlBackend) Name() string { return "pf" }
+
+func (b pfctlBackend) Available() bool {
+	return (runtime.GOOS == "darwin" || runtime.GOOS == "freebsd" || runtime.GOOS == "openbsd") && binaryExists("pfctl")
+}
+
+func (b pfctlBackend) Enabled() (State, error) {
+	out, _ := runCommand("pfctl", "-s", "info")
+
+	if strings.Contains(out, "Status: Enabled") {
+		return StateEnabled, nil
+	}
+
+	if strings.Contains(out, "Status: Disabled") {
+		return StateDisabled, nil
+	}
+
+	return StateNeedsConfig, nil
+}
+
+func (b pfctlBackend) Stealth() (State, error) {
+	return StateUnknown, nil
+}
+
+/* -------------------- macOS Application Firewall -------------------- */
+
+type applicationFirewallBackend struct{}
+
+func (b applicationFirewallBackend) Name() string { return "applicationfirewall" }
+
+func (b applicationFirewallBackend) Available() bool {
+	return runtime.GOOS == "darwin"
+}
+
+func (b applicationFirewallBackend) Enabled() (State, error) {
+	cmd := exec.Command(osxFirewallCmd, "--getglobalstate")
+	str := utils.ExecuteCommand(cmd)
+
+	if statusLabel(str) == "on" {
+		return StateEnabled, nil
+	}
+
+	return StateDisabled, nil
+}
+
+func (b applicationFirewallBackend) Stealth() (State, error) {
+	cmd := exec.Command(osxFirewallCmd, "--getstealthmode")
+	str := utils.ExecuteCommand(cmd)
+
+	if statusLabel(str) == "on" {
+		return StateEnabled, nil
+	}
+
+	return StateDisabled, nil
+}
+
+/* -------------------- Windows per-profile -------------------- */
+
+// windowsProfileState holds the Domain/Private/Public breakdown returned by
+// Get-NetFirewallProfile, instead of collapsing them into a single count
+type windowsProfileState struct {
+	Domain  bool
+	Private bool
+	Public  bool
+}
+
+type windowsProfileBackend struct{}
+
+func (b windowsProfileBackend) Name() string { return "windows" }
+
+func (b windowsProfileBackend) Available() bool {
+	return runtime.GOOS == "windows"
+}
+
+func (b windowsProfileBackend) Enabled() (State, error) {
+	profiles, err := getWindowsFirewallProfiles()
+	if err != nil {
+		return StateUnknown, err
+	}
+
+	switch {
+	case profiles.Domain && profiles.Private && profiles.Public:
+		return StateEnabled, nil
+	case !profiles.Domain && !profiles.Private && !profiles.Public:
+		return StateDisabled, nil
+	default:
+		return StateNeedsConfig, nil
+	}
+}
+
+func (b windowsProfileBackend) Stealth() (State, error) {
+	return StateUnknown, nil
+}
+
+// windowsProfileLabel formats a per-profile breakdown for the widget, e.g.
+// "Domain: On, Private: On, Public: Off"
+func windowsProfileLabel(profiles windowsProfileState) string {
+	return "Domain: " + onOff(profiles.Domain) +
+		", Private: " + onOff(profiles.Private) +
+		", Public: " + onOff(profiles.Public)
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "On"
+	}
+
+	return "Off"
+}
+
+// getWindowsFirewallProfiles runs Get-NetFirewallProfile and parses the
+// Domain/Private/Public enabled state of each profile
+func getWindowsFirewallProfiles() (windowsProfileState, error) {
+	cmd := exec.Command("powershell.exe", "-NoProfile",
+		"-Command", "& { Get-NetFirewallProfile | Select-Object Name,Enabled | ForEach-Object { \"$($_.Name):$($_.Enabled)\" } }")
+
+	out := utils.ExecuteCommand(cmd)
+
+	var profiles windowsProfileState
+
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		enabled := strings.TrimSpace(parts[1]) == "True"
+
+		switch strings.TrimSpace(parts[0]) {
+		case "Domain":
+			profiles.Domain = enabled
+		case "Private":
+			profiles.Private = enabled
+		case "Public":
+			profiles.Public = enabled
+		}
+	}
+
+	return profiles, nil
+}
@@ -0,0 +1,22 @@
+package security
+
+import "testing"
+
+func TestParseFirewalldState(t *testing.T) {
+	tests := []struct {
+		out  string
+		want State
+	}{
+		{"running\n", StateEnabled},
+		{"running", StateEnabled},
+		{"not running\n", StateDisabled},
+		{"not running", StateDisabled},
+		{"", StateDisabled},
+	}
+
+	for _, tt := range tests {
+		if got := parseFirewalldState(tt.out); got != tt.want {
+			t.Errorf("parseFirewalldState(%q) = %v, want %v", tt.out, got, tt.want)
+		}
+	}
+}
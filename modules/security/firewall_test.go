@@ -0,0 +1,72 @@
+package security
+
+import "testing"
+
+// stubBackend is a minimal FirewallBackend for exercising selectFirewallBackend
+// without depending on any real firewall tooling being present
+type stubBackend struct {
+	name      string
+	available bool
+}
+
+func (b stubBackend) Name() string            { return b.name }
+func (b stubBackend) Available() bool         { return b.available }
+func (b stubBackend) Enabled() (State, error) { return StateEnabled, nil }
+func (b stubBackend) Stealth() (State, error) { return StateUnknown, nil }
+
+func TestApplicationFirewallPrecedesPfctlInRegistry(t *testing.T) {
+	appIdx, pfIdx := -1, -1
+
+	for i, backend := range firewallBackends {
+		switch backend.(type) {
+		case applicationFirewallBackend:
+			appIdx = i
+		case pfctlBackend:
+			pfIdx = i
+		}
+	}
+
+	if appIdx == -1 || pfIdx == -1 {
+		t.Fatalf("expected both applicationFirewallBackend and pfctlBackend in the registry, got %v", firewallBackends)
+	}
+
+	if appIdx > pfIdx {
+		t.Fatalf("applicationFirewallBackend (index %d) must come before pfctlBackend (index %d): pfctl is present on nearly every stock macOS install and would otherwise shadow the user-facing Application Firewall probe", appIdx, pfIdx)
+	}
+}
+
+func TestSelectFirewallBackendPicksFirstAvailable(t *testing.T) {
+	original := firewallBackends
+	defer func() { firewallBackends = original }()
+
+	firewallBackends = []FirewallBackend{
+		stubBackend{name: "unavailable", available: false},
+		stubBackend{name: "first-available", available: true},
+		stubBackend{name: "second-available", available: true},
+	}
+
+	backend := selectFirewallBackend()
+	if backend == nil || backend.Name() != "first-available" {
+		t.Fatalf("expected first-available backend to be selected, got %v", backend)
+	}
+}
+
+func TestSelectFirewallBackendHonorsForcedBackend(t *testing.T) {
+	original := firewallBackends
+	defer func() { firewallBackends = original }()
+
+	originalForced := forcedBackend
+	defer func() { forcedBackend = originalForced }()
+
+	firewallBackends = []FirewallBackend{
+		stubBackend{name: "first-available", available: true},
+		stubBackend{name: "forced", available: true},
+	}
+
+	SetForcedFirewallBackend("forced")
+
+	backend := selectFirewallBackend()
+	if backend == nil || backend.Name() != "forced" {
+		t.Fatalf("expected the forced backend to be selected, got %v", backend)
+	}
+}
@@ -0,0 +1,28 @@
+package security
+
+import (
+	"github.com/olebedev/config"
+	"github.com/wtfutil/wtf/cfg"
+)
+
+// Settings is the config for the Security module, read from the
+// wtf.mods.security tree in config.yml
+type Settings struct {
+	*cfg.Common
+
+	firewallBackend string `help:"Force a specific firewall backend instead of auto-selecting one. One of: ufw, firewalld, nftables, iptables, pf, applicationfirewall, windows." optional:"true"`
+}
+
+// NewSettingsFromYAML creates a new settings instance from a YAML config block,
+// applying security.firewallBackend (if set) as the forced firewall backend
+func NewSettingsFromYAML(name string, moduleConfig *config.Config, globalConfig *config.Config) *Settings {
+	settings := Settings{
+		Common: cfg.NewCommonSettingsFromModule(name, "security", globalConfig, moduleConfig),
+
+		firewallBackend: moduleConfig.UString("firewallBackend", ""),
+	}
+
+	SetForcedFirewallBackend(settings.firewallBackend)
+
+	return &settings
+}